@@ -0,0 +1,190 @@
+package model
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrAckNotPending = errors.New("no pending ack for this phone/serial/msgId")
+)
+
+// RetryPolicy 描述下行指令未收到0x0001应答时的重试策略：指数退避，超过最大次数后放弃
+type RetryPolicy struct {
+	Timeout     time.Duration // 每次发送后等待应答的超时时间
+	MaxAttempts int           // 最多发送次数，含首次发送
+	Backoff     float64       // 每次重试超时时间相对上一次的倍数，例如2.0表示指数退避
+}
+
+// DefaultRetryPolicy 平台下行指令的默认重试策略：5秒超时，最多发送3次（首次发送+2次重发），指数退避
+var DefaultRetryPolicy = RetryPolicy{Timeout: 5 * time.Second, MaxAttempts: 3, Backoff: 2.0}
+
+type ackKey struct {
+	PhoneNumber  string
+	SerialNumber uint16
+	MsgID        uint16
+}
+
+// PendingAck 一条已发送、等待终端0x0001应答的下行指令
+type PendingAck struct {
+	key     ackKey
+	raw     []byte // 原始已编码指令字节，超时重发时原样重新发送
+	policy  RetryPolicy
+	send    func([]byte) error
+	result  chan ResultCode // 终端应答后写入ResultCode，超时放弃时关闭
+	attempt int
+	timer   *time.Timer
+	mu      sync.Mutex
+	done    bool
+
+	finalize sync.Once // 保证result只被Resolve或onTimeout两者之一发送/关闭一次
+}
+
+// Result 阻塞等待该指令的终端应答结果，超时重试耗尽后channel被关闭，收到零值
+func (p *PendingAck) Result() <-chan ResultCode { return p.result }
+
+// AckTrackerMetrics 记录AckTracker的运行态指标，供监控采集
+type AckTrackerMetrics struct {
+	Pending  int64
+	TimedOut int64
+	Failed   int64
+}
+
+// AckTracker 跟踪每一条已下发的平台指令，直到收到终端对应的0x0001通用应答。
+// 超时未应答时按RetryPolicy原样重发，重试次数耗尽后判定为失败。
+type AckTracker struct {
+	mu      sync.Mutex
+	pending map[ackKey]*PendingAck
+
+	timedOut int64
+	failed   int64
+}
+
+// NewAckTracker 构造一个AckTracker
+func NewAckTracker() *AckTracker {
+	return &AckTracker{pending: make(map[ackKey]*PendingAck)}
+}
+
+// Register 登记一条已发送的下行指令，raw是已经编码好的原始字节，send用于超时后原样重发。
+// 返回的PendingAck可用于等待应答结果。
+func (t *AckTracker) Register(phoneNumber string, serialNumber uint16, msgID uint16, raw []byte, send func([]byte) error, policy RetryPolicy) *PendingAck {
+	p := &PendingAck{
+		key:    ackKey{PhoneNumber: phoneNumber, SerialNumber: serialNumber, MsgID: msgID},
+		raw:    raw,
+		policy: policy,
+		send:   send,
+		result: make(chan ResultCode, 1),
+	}
+
+	t.mu.Lock()
+	t.pending[p.key] = p
+	t.mu.Unlock()
+
+	// p.timer必须在持有p.mu时赋值：超时时间很短时，计时器回调可能在time.AfterFunc返回前就已触发，
+	// onTimeout一进来就会尝试获取p.mu，借助这把锁保证它读到p.timer时赋值已经完成
+	p.mu.Lock()
+	p.timer = time.AfterFunc(policy.Timeout, func() { t.onTimeout(p) })
+	p.mu.Unlock()
+
+	return p
+}
+
+// TrackSend 编码并发送一条下行指令，同时登记进AckTracker等待终端0x0001应答；
+// send失败时直接返回错误，不登记，避免跟踪一条根本没发出去的指令。
+func (t *AckTracker) TrackSend(cmd JT808Cmd, send func([]byte) error, policy RetryPolicy) (*PendingAck, error) {
+	raw, err := cmd.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := send(raw); err != nil {
+		return nil, err
+	}
+
+	header := cmd.GetHeader()
+	return t.Register(header.PhoneNumber, header.SerialNumber, header.MsgID, raw, send, policy), nil
+}
+
+// HandleMsg0001 用终端上报的0x0001通用应答消解对应的待确认指令，返回false表示没有匹配的待确认指令
+func (t *AckTracker) HandleMsg0001(msg *Msg0001) bool {
+	return t.Resolve(msg.Header.PhoneNumber, msg.AnswerSerialNumber, msg.AnswerMessageID, msg.Result)
+}
+
+// Resolve 用终端上报的0x0001通用应答解析出的字段，消解对应的待确认指令。
+// 返回false表示没有找到匹配的待确认指令（可能已超时放弃，或是重复应答）。
+func (t *AckTracker) Resolve(phoneNumber string, answerSerialNumber uint16, answerMsgID uint16, result ResultCode) bool {
+	key := ackKey{PhoneNumber: phoneNumber, SerialNumber: answerSerialNumber, MsgID: answerMsgID}
+
+	t.mu.Lock()
+	p, ok := t.pending[key]
+	if ok {
+		delete(t.pending, key)
+	}
+	t.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	p.mu.Lock()
+	p.done = true
+	p.timer.Stop()
+	p.mu.Unlock()
+
+	p.finalize.Do(func() {
+		p.result <- result
+		close(p.result)
+	})
+
+	return true
+}
+
+func (t *AckTracker) onTimeout(p *PendingAck) {
+	p.mu.Lock()
+	if p.done {
+		p.mu.Unlock()
+		return
+	}
+	p.attempt++
+
+	if p.attempt >= p.policy.MaxAttempts {
+		p.done = true
+		p.mu.Unlock()
+
+		t.mu.Lock()
+		delete(t.pending, p.key)
+		t.mu.Unlock()
+
+		atomic.AddInt64(&t.failed, 1)
+		p.finalize.Do(func() { close(p.result) })
+		return
+	}
+
+	nextTimeout := time.Duration(float64(p.policy.Timeout) * math.Pow(p.policy.Backoff, float64(p.attempt)))
+	p.timer.Reset(nextTimeout)
+	p.mu.Unlock()
+
+	atomic.AddInt64(&t.timedOut, 1)
+
+	if err := p.send(p.raw); err != nil {
+		// 重发失败也按下一次超时处理，留给下一轮定时器继续重试或最终判定失败
+		return
+	}
+}
+
+// Metrics 返回当前的待确认/超时重试/最终失败计数，供监控采集
+func (t *AckTracker) Metrics() AckTrackerMetrics {
+	t.mu.Lock()
+	pending := int64(len(t.pending))
+	t.mu.Unlock()
+
+	return AckTrackerMetrics{
+		Pending:  pending,
+		TimedOut: atomic.LoadInt64(&t.timedOut),
+		Failed:   atomic.LoadInt64(&t.failed),
+	}
+}
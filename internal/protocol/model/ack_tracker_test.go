@@ -0,0 +1,147 @@
+package model
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TestAckTrackerResolveAfterTimeoutDoesNotPanic 复现Resolve与onTimeout耗尽重试次数并发
+// 竞争同一个PendingAck的场景：两者都可能尝试发送/关闭同一个result channel，
+// 不应该出现"send on closed channel"的panic。
+func TestAckTrackerResolveAfterTimeoutDoesNotPanic(t *testing.T) {
+	tracker := NewAckTracker()
+	policy := RetryPolicy{Timeout: time.Microsecond, MaxAttempts: 1, Backoff: 2.0}
+
+	for i := 0; i < 200; i++ {
+		p := tracker.Register("013912345678", uint16(i), 0x8100, []byte{0x01}, func([]byte) error { return nil }, policy)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.Resolve("013912345678", uint16(i), 0x8100, ResultSuccess)
+		}()
+
+		// 等待onTimeout有机会耗尽重试次数，与Resolve产生竞争
+		time.Sleep(200 * time.Microsecond)
+		wg.Wait()
+
+		// 排空channel，避免阻塞其他goroutine（如果Resolve赢得了竞争）
+		select {
+		case <-p.Result():
+		default:
+		}
+	}
+}
+
+// TestAckTrackerResolveDeliversResult 正常应答场景下，Resolve应找到对应的待确认指令，
+// 并把ResultCode投递到PendingAck.Result()
+func TestAckTrackerResolveDeliversResult(t *testing.T) {
+	tracker := NewAckTracker()
+	p := tracker.Register("013912345678", 1, 0x8100, []byte{0x01}, func([]byte) error { return nil }, DefaultRetryPolicy)
+
+	if !tracker.Resolve("013912345678", 1, 0x8100, ResultSuccess) {
+		t.Fatalf("expected Resolve to find the pending ack")
+	}
+
+	select {
+	case result := <-p.Result():
+		if result != ResultSuccess {
+			t.Fatalf("expected ResultSuccess, got %v", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for result")
+	}
+
+	if tracker.Resolve("013912345678", 1, 0x8100, ResultSuccess) {
+		t.Fatal("expected second Resolve for the same ack to report not found")
+	}
+}
+
+// TestAckTrackerRetriesAndFails 验证超过MaxAttempts后，指令被判定为失败，
+// result channel被关闭而不是挂起，且Failed计数为1
+func TestAckTrackerRetriesAndFails(t *testing.T) {
+	tracker := NewAckTracker()
+	policy := RetryPolicy{Timeout: time.Millisecond, MaxAttempts: 3, Backoff: 1.0}
+
+	var resends int32
+	var mu sync.Mutex
+	p := tracker.Register("013912345678", 1, 0x8100, []byte{0x01}, func([]byte) error {
+		mu.Lock()
+		resends++
+		mu.Unlock()
+		return nil
+	}, policy)
+
+	select {
+	case result, ok := <-p.Result():
+		if ok {
+			t.Fatalf("expected closed channel after retries exhausted, got result %v", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for ack to fail")
+	}
+
+	metrics := tracker.Metrics()
+	if metrics.Failed != 1 {
+		t.Fatalf("expected Failed=1, got %d", metrics.Failed)
+	}
+}
+
+// TestAckTrackerTrackSendRegistersAndHandleMsg0001Resolves 验证发送路径上的埋点：
+// TrackSend发出指令后应登记进AckTracker，终端随后上报的0x0001应答经HandleMsg0001
+// 能找到并消解这条待确认指令。
+func TestAckTrackerTrackSendRegistersAndHandleMsg0001Resolves(t *testing.T) {
+	tracker := NewAckTracker()
+	header := &MsgHeader{PhoneNumber: "013912345678", SerialNumber: 7}
+	cmd := &Cmd8100{Header: header, AnswerSerialNumber: 1, Result: ResSuccess, AuthCode: "AuthCode"}
+
+	var sent []byte
+	p, err := tracker.TrackSend(cmd, func(b []byte) error { sent = b; return nil }, DefaultRetryPolicy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sent == nil {
+		t.Fatalf("expected TrackSend to invoke send with the encoded command")
+	}
+
+	ack := &Msg0001{
+		Header:             &MsgHeader{PhoneNumber: header.PhoneNumber},
+		AnswerSerialNumber: header.SerialNumber,
+		AnswerMessageID:    header.MsgID,
+		Result:             ResultSuccess,
+	}
+	if !tracker.HandleMsg0001(ack) {
+		t.Fatalf("expected HandleMsg0001 to resolve the command tracked by TrackSend")
+	}
+
+	select {
+	case result := <-p.Result():
+		if result != ResultSuccess {
+			t.Fatalf("expected ResultSuccess, got %v", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for result")
+	}
+}
+
+// TestAckTrackerTrackSendDoesNotRegisterOnSendFailure 发送失败时不应该登记进AckTracker，
+// 否则会跟踪一条根本没送达终端的指令，白白等待永远不会到来的应答。
+func TestAckTrackerTrackSendDoesNotRegisterOnSendFailure(t *testing.T) {
+	tracker := NewAckTracker()
+	header := &MsgHeader{PhoneNumber: "013912345678", SerialNumber: 9}
+	cmd := &Cmd8100{Header: header, AnswerSerialNumber: 1, Result: ResSuccess, AuthCode: "AuthCode"}
+
+	sendErr := errors.New("connection closed")
+	_, err := tracker.TrackSend(cmd, func([]byte) error { return sendErr }, DefaultRetryPolicy)
+	if err != sendErr {
+		t.Fatalf("expected TrackSend to propagate the send error, got %v", err)
+	}
+
+	if tracker.Metrics().Pending != 0 {
+		t.Fatalf("expected nothing registered after a failed send")
+	}
+}
@@ -7,6 +7,7 @@ import (
 type JT808Cmd interface {
 	GenCmd(JT808Msg) error
 	Encode() ([]byte, error) // struct -> []byte
+	GetHeader() *MsgHeader
 }
 
 type ResultCode uint8
@@ -26,6 +27,8 @@ type Cmd8001 struct {
 	Result             ResultCode `json:"result"`             // 结果，0成功/确认，1失败，2消息有误，3不支持
 }
 
+func (c *Cmd8001) GetHeader() *MsgHeader { return c.Header }
+
 func (c *Cmd8001) GenCmd(msg JT808Msg) error {
 	header := msg.GetHeader()
 	c.AnswerSerialNumber = header.SerialNumber
@@ -34,6 +37,7 @@ func (c *Cmd8001) GenCmd(msg JT808Msg) error {
 
 	c.Header = header
 	c.Header.MsgID = 0x8001
+	assignSerialNumber(c.Header)
 
 	return nil
 }
@@ -49,7 +53,7 @@ func (c *Cmd8001) Encode() (pkt []byte, err error) {
 
 	pkt = append(pkt, byte(c.Result))
 
-	c.Header.Attr.BodyLength = uint16(len(pkt))
+	c.Header.BodyLength = uint16(len(pkt))
 
 	headerPkt, err := c.Header.Encode()
 	if err != nil {
@@ -79,6 +83,8 @@ type Cmd8100 struct {
 	AuthCode           string     `json:"authCode"`           // 鉴权码
 }
 
+func (c *Cmd8100) GetHeader() *MsgHeader { return c.Header }
+
 func (c *Cmd8100) GenCmd(msg JT808Msg) error {
 	m := msg.(*Msg0100)
 	c.AnswerSerialNumber = m.Header.SerialNumber
@@ -86,7 +92,8 @@ func (c *Cmd8100) GenCmd(msg JT808Msg) error {
 	c.AuthCode = "AuthCode" // 初始值，在后续处理中根据id重写
 
 	c.Header = m.Header
-	c.Header.MsgID = 0x8100
+	c.Header.MsgID = replyIDOrDefault(msg, 0x8100)
+	assignSerialNumber(c.Header)
 
 	return nil
 }
@@ -100,7 +107,7 @@ func (c *Cmd8100) Encode() (pkt []byte, err error) {
 
 	pkt = append(pkt, []byte(c.AuthCode)...)
 
-	c.Header.Attr.BodyLength = uint16(len(pkt))
+	c.Header.BodyLength = uint16(len(pkt))
 
 	headerPkt, err := c.Header.Encode()
 	if err != nil {
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrCryptorNotConfigured = errors.New("cryptor not configured with key material")
+)
+
+// Cryptor 对消息体进行加解密，与 MsgBodyAttr.Encryption 声明的加密类型一一对应
+type Cryptor interface {
+	Encrypt(body []byte) ([]byte, error)
+	Decrypt(body []byte) ([]byte, error)
+	Name() string // 对应的加密类型，参见 EncryptionNone/EncryptionRSA
+}
+
+// noopCryptor 不加密，原样透传消息体，对应 EncryptionNone
+type noopCryptor struct{}
+
+func (c *noopCryptor) Encrypt(body []byte) ([]byte, error) { return body, nil }
+func (c *noopCryptor) Decrypt(body []byte) ([]byte, error) { return body, nil }
+func (c *noopCryptor) Name() string                        { return EncryptionNone }
+
+// RSACryptor 实现JT808约定的RSA加密方式：平台持有RSA密钥对，公钥(e/n)通过0x8A00下发给终端，
+// 终端用平台公钥加密消息体上报，平台用私钥解密。公钥只能由配对的私钥解开，终端并不持有平台私钥，
+// 因此该加密方向是单向的：平台下发的应答消息体不用这把公钥加密，沿用明文下发，与大多数JT808部署一致。
+//
+// 支持密钥轮转：轮转后仍保留上一把私钥一段时间，用于解密轮转前终端已经用旧公钥加密、
+// 尚在链路上的消息，避免轮转瞬间丢消息。
+type RSACryptor struct {
+	priv     *rsa.PrivateKey
+	pub      *rsa.PublicKey
+	prevPriv *rsa.PrivateKey
+}
+
+// NewRSACryptor 用平台的RSA密钥对构造一个RSACryptor
+func NewRSACryptor(priv *rsa.PrivateKey, pub *rsa.PublicKey) *RSACryptor {
+	return &RSACryptor{priv: priv, pub: pub}
+}
+
+// Rotate 轮转平台RSA密钥对，旧私钥保留用于兼容在途消息，新公钥由调用方通过0x8A00下发给终端
+func (c *RSACryptor) Rotate(priv *rsa.PrivateKey, pub *rsa.PublicKey) {
+	c.prevPriv = c.priv
+	c.priv = priv
+	c.pub = pub
+}
+
+// PublicKey 返回当前下发给终端的公钥，用于生成0x8A00消息体
+func (c *RSACryptor) PublicKey() *rsa.PublicKey { return c.pub }
+
+func (c *RSACryptor) Encrypt(body []byte) ([]byte, error) {
+	if c.pub == nil {
+		return nil, ErrCryptorNotConfigured
+	}
+	return rsa.EncryptPKCS1v15(rand.Reader, c.pub, body)
+}
+
+func (c *RSACryptor) Decrypt(body []byte) ([]byte, error) {
+	if c.priv == nil {
+		return nil, ErrCryptorNotConfigured
+	}
+	plain, err := rsa.DecryptPKCS1v15(rand.Reader, c.priv, body)
+	if err == nil {
+		return plain, nil
+	}
+	if c.prevPriv != nil {
+		if prevPlain, prevErr := rsa.DecryptPKCS1v15(rand.Reader, c.prevPriv, body); prevErr == nil {
+			return prevPlain, nil
+		}
+	}
+	return nil, errors.Wrap(err, "rsa decrypt body error")
+}
+
+func (c *RSACryptor) Name() string { return EncryptionRSA }
+
+// cryptors 已注册的加解密实现，按加密类型查找，默认只有不加密的透传实现
+var cryptors = map[string]Cryptor{
+	EncryptionNone: &noopCryptor{},
+}
+
+// RegisterCryptor 注册一种加密类型对应的Cryptor实现，例如启动时装配平台的RSA密钥对
+func RegisterCryptor(c Cryptor) {
+	cryptors[c.Name()] = c
+}
+
+// CryptorFor 按加密类型查找对应的Cryptor，未注册时退化为不加密的透传实现
+func CryptorFor(encryption string) Cryptor {
+	if c, ok := cryptors[encryption]; ok {
+		return c
+	}
+	return cryptors[EncryptionNone]
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"testing"
+)
+
+// TestRSACryptorRoundTripLocationReport 模拟终端用平台公钥加密0x0200位置上报消息体上行，
+// 平台收到后用私钥解密，验证MsgHeader.Decode能还原出明文消息体。
+func TestRSACryptorRoundTripLocationReport(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate rsa key error: %v", err)
+	}
+	cryptor := NewRSACryptor(priv, &priv.PublicKey)
+	RegisterCryptor(cryptor)
+	defer delete(cryptors, EncryptionRSA) // 恢复默认，避免影响其他用例
+
+	// 0x0200位置上报消息体：报警标志位(4) + 状态位(4) + 纬度(4) + 经度(4) + 高程(2)
+	plain := make([]byte, 18)
+	binary.BigEndian.PutUint32(plain[8:12], 31123456)   // 纬度
+	binary.BigEndian.PutUint32(plain[12:16], 121234567) // 经度
+
+	encrypted, err := cryptor.Encrypt(plain)
+	if err != nil {
+		t.Fatalf("encrypt error: %v", err)
+	}
+
+	attr := MsgBodyAttr{BodyLength: uint16(len(encrypted)), encryptionOriginal: 0b001}
+	attrPkt, err := attr.Encode()
+	if err != nil {
+		t.Fatalf("encode body attr error: %v", err)
+	}
+
+	pkt := make([]byte, 0)
+	id := make([]byte, 2)
+	binary.BigEndian.PutUint16(id, 0x0200)
+	pkt = append(pkt, id...)
+	pkt = append(pkt, attrPkt...)
+	pkt = append(pkt, make([]byte, 6)...) // 终端手机号，2013版本6字节BCD
+	serial := make([]byte, 2)
+	binary.BigEndian.PutUint16(serial, 1)
+	pkt = append(pkt, serial...)
+	pkt = append(pkt, encrypted...)
+
+	decoded := &MsgHeader{}
+	if err := decoded.Decode(pkt); err != nil {
+		t.Fatalf("decode header error: %v", err)
+	}
+
+	if !bytes.Equal(decoded.Body, plain) {
+		t.Fatalf("decrypted body mismatch, got %x, want %x", decoded.Body, plain)
+	}
+	if decoded.BodyLength != uint16(len(plain)) {
+		t.Fatalf("decrypted body length mismatch, got %d, want %d", decoded.BodyLength, len(plain))
+	}
+}
+
+// TestRSACryptorRotateKeepsDecryptingInFlightMessages 验证密钥轮转后，
+// 仍能解密轮转前用旧公钥加密、尚在链路上的消息。
+func TestRSACryptorRotateKeepsDecryptingInFlightMessages(t *testing.T) {
+	oldPriv, _ := rsa.GenerateKey(rand.Reader, 1024)
+	cryptor := NewRSACryptor(oldPriv, &oldPriv.PublicKey)
+
+	plain := []byte("in-flight location report")
+	encrypted, err := cryptor.Encrypt(plain)
+	if err != nil {
+		t.Fatalf("encrypt error: %v", err)
+	}
+
+	newPriv, _ := rsa.GenerateKey(rand.Reader, 1024)
+	cryptor.Rotate(newPriv, &newPriv.PublicKey)
+
+	decrypted, err := cryptor.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("decrypt in-flight message after rotate error: %v", err)
+	}
+	if !bytes.Equal(decrypted, plain) {
+		t.Fatalf("decrypted mismatch, got %q, want %q", decrypted, plain)
+	}
+}
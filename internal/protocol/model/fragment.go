@@ -0,0 +1,205 @@
+package model
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrFragmentIndexOutOfRange = errors.New("fragment index out of range of total")
+	ErrFragmentBackpressure    = errors.New("too many pending fragment sets for device")
+)
+
+// fragmentKey 唯一标识一组分包：同一终端、同一消息类型、同一组分包的首包流水号
+type fragmentKey struct {
+	PhoneNumber  string
+	MsgID        uint16
+	SerialNumber uint16 // 该组分包中第一个包的流水号
+}
+
+// fragmentSlab 缓存一组分包的中间状态
+type fragmentSlab struct {
+	header   *MsgHeader // 该组中最先收到的分包的消息头（不一定是Index为1的包），用作合包后消息头的模板
+	total    uint16
+	bodies   [][]byte // 按Index-1下标存放每个分包的消息体，下标从0开始
+	received []bool   // 位图，标记对应下标是否已收到
+	count    uint16
+	expireAt time.Time
+}
+
+// FragmentAssembler 缓存并重组JT808的分包消息（如0x0801多媒体数据上传、0x0700行驶记录），
+// 在收齐Total个分包之前持续缓冲，收齐后合并成一条PacketFragmented=false的完整消息体，
+// 交回正常的解码流程继续分发给JT808Msg handler。
+type FragmentAssembler struct {
+	mu sync.Mutex
+
+	slabs map[fragmentKey]*fragmentSlab
+
+	ttl               time.Duration // 分包集合的存活时间，超时未收齐视为丢包
+	maxSlabsPerDevice int           // 单个终端允许同时在途的分包集合数，超过后拒绝新的分包组，防止内存被打爆
+}
+
+// NewFragmentAssembler 构造一个分包重组器
+func NewFragmentAssembler(ttl time.Duration, maxSlabsPerDevice int) *FragmentAssembler {
+	return &FragmentAssembler{
+		slabs:             make(map[fragmentKey]*fragmentSlab),
+		ttl:               ttl,
+		maxSlabsPerDevice: maxSlabsPerDevice,
+	}
+}
+
+// Add 喂入一个分包，header.PacketFragmented必须为true。
+// 当这是该分包集合的最后一片时，返回合并后的消息头与消息体，done=true；
+// 否则done=false，调用方应continue等待后续分包。
+func (fa *FragmentAssembler) Add(header *MsgHeader, body []byte) (mergedHeader *MsgHeader, mergedBody []byte, done bool, err error) {
+	if header.Total == 0 || header.Index == 0 || header.Index > header.Total {
+		return nil, nil, false, ErrFragmentIndexOutOfRange
+	}
+
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+
+	key := fa.keyOf(header)
+
+	slab, ok := fa.slabs[key]
+	if !ok {
+		if fa.devicePendingSlabs(header.PhoneNumber) >= fa.maxSlabsPerDevice {
+			return nil, nil, false, ErrFragmentBackpressure
+		}
+		slab = &fragmentSlab{
+			header:   header,
+			total:    header.Total,
+			bodies:   make([][]byte, header.Total),
+			received: make([]bool, header.Total),
+		}
+		fa.slabs[key] = slab
+	}
+	slab.expireAt = time.Now().Add(fa.ttl)
+
+	i := header.Index - 1
+	if !slab.received[i] {
+		slab.received[i] = true
+		slab.bodies[i] = body
+		slab.count++
+	}
+
+	if slab.count < slab.total {
+		return nil, nil, false, nil
+	}
+
+	delete(fa.slabs, key)
+
+	merged := make([]byte, 0, len(body)*int(slab.total))
+	for _, b := range slab.bodies {
+		merged = append(merged, b...)
+	}
+
+	mergedHeader = slab.header
+	mergedHeader.PacketFragmented = false
+	mergedHeader.MsgFragmentation = MsgFragmentation{}
+	mergedHeader.BodyLength = uint16(len(merged))
+	mergedHeader.Body = merged
+
+	return mergedHeader, merged, true, nil
+}
+
+// Sweep 扫描所有缓存中的分包集合，回收超时未收齐的，并为每个超时集合生成一条
+// 0x8003 "补传分包请求"，列出缺失的包序号
+func (fa *FragmentAssembler) Sweep(now time.Time) []*Cmd8003 {
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+
+	var resends []*Cmd8003
+	for key, slab := range fa.slabs {
+		if now.Before(slab.expireAt) {
+			continue
+		}
+
+		missing := make([]uint16, 0, slab.total-slab.count)
+		for idx, got := range slab.received {
+			if !got {
+				missing = append(missing, uint16(idx)+1)
+			}
+		}
+
+		slab.header.MsgID = 0x8003
+		assignSerialNumber(slab.header)
+
+		resends = append(resends, &Cmd8003{
+			Header:             slab.header,
+			AnswerSerialNumber: key.SerialNumber,
+			Total:              slab.total,
+			MissingIndices:     missing,
+		})
+
+		delete(fa.slabs, key)
+	}
+	return resends
+}
+
+func (fa *FragmentAssembler) keyOf(header *MsgHeader) fragmentKey {
+	return fragmentKey{
+		PhoneNumber:  header.PhoneNumber,
+		MsgID:        header.MsgID,
+		SerialNumber: header.SerialNumber - header.Index + 1, // 还原首包流水号
+	}
+}
+
+func (fa *FragmentAssembler) devicePendingSlabs(phoneNumber string) int {
+	n := 0
+	for key := range fa.slabs {
+		if key.PhoneNumber == phoneNumber {
+			n++
+		}
+	}
+	return n
+}
+
+// Cmd8003 补传分包请求，平台在分包超时未收齐时下发，要求终端补传缺失的分包
+type Cmd8003 struct {
+	Header             *MsgHeader `json:"header"`
+	AnswerSerialNumber uint16     `json:"answerSerialNumber"` // 首包流水号
+	Total              uint16     `json:"total"`              // 分包总数
+	MissingIndices     []uint16   `json:"missingIndices"`     // 缺失的包序号列表
+}
+
+func (c *Cmd8003) GetHeader() *MsgHeader { return c.Header }
+
+func (c *Cmd8003) GenCmd(msg JT808Msg) error {
+	header := msg.GetHeader()
+	c.AnswerSerialNumber = header.SerialNumber
+
+	c.Header = header
+	c.Header.MsgID = replyIDOrDefault(msg, 0x8003)
+	assignSerialNumber(c.Header)
+
+	return nil
+}
+
+func (c *Cmd8003) Encode() (pkt []byte, err error) {
+	asn := make([]byte, 2)
+	binary.BigEndian.PutUint16(asn, c.AnswerSerialNumber)
+	pkt = append(pkt, asn...)
+
+	pkt = append(pkt, byte(len(c.MissingIndices)))
+
+	for _, idx := range c.MissingIndices {
+		b := make([]byte, 2)
+		binary.BigEndian.PutUint16(b, idx)
+		pkt = append(pkt, b...)
+	}
+
+	c.Header.BodyLength = uint16(len(pkt))
+
+	headerPkt, err := c.Header.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	pkt = append(headerPkt, pkt...)
+
+	return
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func fragmentHeader(phoneNumber string, msgID uint16, firstSerial, index, total uint16) *MsgHeader {
+	return &MsgHeader{
+		MsgID:            msgID,
+		PhoneNumber:      phoneNumber,
+		SerialNumber:     firstSerial + index - 1,
+		MsgFragmentation: MsgFragmentation{Total: total, Index: index},
+	}
+}
+
+func TestFragmentAssemblerReassemblesInOrder(t *testing.T) {
+	fa := NewFragmentAssembler(time.Minute, 4)
+
+	if _, _, done, err := fa.Add(fragmentHeader("013912345678", 0x0801, 1, 1, 3), []byte("AAA")); err != nil || done {
+		t.Fatalf("expected not done after first fragment, got done=%v err=%v", done, err)
+	}
+	if _, _, done, err := fa.Add(fragmentHeader("013912345678", 0x0801, 1, 2, 3), []byte("BBB")); err != nil || done {
+		t.Fatalf("expected not done after second fragment, got done=%v err=%v", done, err)
+	}
+
+	mergedHeader, mergedBody, done, err := fa.Add(fragmentHeader("013912345678", 0x0801, 1, 3, 3), []byte("CCC"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !done {
+		t.Fatalf("expected done after final fragment")
+	}
+	if !bytes.Equal(mergedBody, []byte("AAABBBCCC")) {
+		t.Fatalf("merged body mismatch, got %q", mergedBody)
+	}
+	if mergedHeader.PacketFragmented {
+		t.Fatalf("expected merged header to clear PacketFragmented")
+	}
+	if mergedHeader.BodyLength != uint16(len(mergedBody)) {
+		t.Fatalf("expected BodyLength to match merged body length, got %d", mergedHeader.BodyLength)
+	}
+}
+
+func TestFragmentAssemblerOutOfOrder(t *testing.T) {
+	fa := NewFragmentAssembler(time.Minute, 4)
+
+	fa.Add(fragmentHeader("013912345678", 0x0801, 1, 3, 3), []byte("CCC"))
+	fa.Add(fragmentHeader("013912345678", 0x0801, 1, 1, 3), []byte("AAA"))
+
+	_, mergedBody, done, err := fa.Add(fragmentHeader("013912345678", 0x0801, 1, 2, 3), []byte("BBB"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !done {
+		t.Fatalf("expected done after all fragments received out of order")
+	}
+	if !bytes.Equal(mergedBody, []byte("AAABBBCCC")) {
+		t.Fatalf("merged body should be reordered by Index, got %q", mergedBody)
+	}
+}
+
+func TestFragmentAssemblerBackpressure(t *testing.T) {
+	fa := NewFragmentAssembler(time.Minute, 1)
+
+	if _, _, _, err := fa.Add(fragmentHeader("013912345678", 0x0801, 1, 1, 3), []byte("AAA")); err != nil {
+		t.Fatalf("unexpected error starting first fragment set: %v", err)
+	}
+
+	_, _, _, err := fa.Add(fragmentHeader("013912345678", 0x0801, 10, 1, 2), []byte("XXX"))
+	if err != ErrFragmentBackpressure {
+		t.Fatalf("expected ErrFragmentBackpressure for a second in-flight set on the same device, got %v", err)
+	}
+}
+
+func TestFragmentAssemblerSweepGeneratesMissingIndicesAndEvicts(t *testing.T) {
+	fa := NewFragmentAssembler(time.Millisecond, 4)
+
+	fa.Add(fragmentHeader("013912345678", 0x0801, 1, 1, 3), []byte("AAA"))
+	fa.Add(fragmentHeader("013912345678", 0x0801, 1, 3, 3), []byte("CCC"))
+
+	time.Sleep(5 * time.Millisecond)
+
+	resends := fa.Sweep(time.Now())
+	if len(resends) != 1 {
+		t.Fatalf("expected exactly one timed-out fragment set, got %d", len(resends))
+	}
+	if len(resends[0].MissingIndices) != 1 || resends[0].MissingIndices[0] != 2 {
+		t.Fatalf("expected missing index [2], got %v", resends[0].MissingIndices)
+	}
+	if resends[0].Header.MsgID != 0x8003 {
+		t.Fatalf("expected resend header MsgID to be 0x8003, got %#04x", resends[0].Header.MsgID)
+	}
+
+	// 超时后的分包组应该被清理，后续即便补发了剩余分包也不会再被拼接成功
+	_, _, done, err := fa.Add(fragmentHeader("013912345678", 0x0801, 1, 2, 3), []byte("BBB"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if done {
+		t.Fatalf("expected the evicted fragment set to have restarted, not resumed to completion with a single fragment")
+	}
+}
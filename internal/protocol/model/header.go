@@ -21,7 +21,8 @@ type MsgHeader struct {
 	SerialNumber     uint16 `json:"serialNumber"`    // 消息流水号
 	MsgFragmentation        // 消息包封装项
 
-	Idx int32 `json:"-"` // 读取的packet header下标ID
+	Idx  int32  `json:"-"` // 读取的packet header下标ID
+	Body []byte `json:"-"` // 解密后的消息体，Decode时按Encryption字段解出
 }
 
 // 将[]byte解码成消息头结构体
@@ -64,6 +65,21 @@ func (h *MsgHeader) Decode(pkt []byte) error {
 
 	h.Idx = idx
 
+	if idx+int32(h.BodyLength) > int32(len(pkt)) {
+		return ErrDecodeHeader
+	}
+
+	body := pkt[idx : idx+int32(h.BodyLength)]
+	if h.encryptionOriginal != 0b000 {
+		plain, err := CryptorFor(h.Encryption).Decrypt(body)
+		if err != nil {
+			return errors.Wrap(ErrDecodeHeader, err.Error())
+		}
+		body = plain
+		h.BodyLength = uint16(len(body))
+	}
+	h.Body = body
+
 	return nil
 }
 
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestDecodeTruncatedBodyLengthReturnsError 消息头声明的BodyLength超出了实际报文剩余长度时
+// （截断帧或伪造的畸形帧），Decode应该返回错误而不是越界panic。
+func TestDecodeTruncatedBodyLengthReturnsError(t *testing.T) {
+	attr := MsgBodyAttr{BodyLength: 50} // 声称消息体50字节
+	attrPkt, err := attr.Encode()
+	if err != nil {
+		t.Fatalf("encode body attr error: %v", err)
+	}
+
+	pkt := make([]byte, 0)
+	id := make([]byte, 2)
+	binary.BigEndian.PutUint16(id, 0x0200)
+	pkt = append(pkt, id...)
+	pkt = append(pkt, attrPkt...)
+	pkt = append(pkt, make([]byte, 6)...) // 终端手机号
+	serial := make([]byte, 2)
+	binary.BigEndian.PutUint16(serial, 1)
+	pkt = append(pkt, serial...)
+	pkt = append(pkt, []byte{0x01, 0x02, 0x03}...) // 实际只剩3字节，远少于声明的50字节
+
+	h := &MsgHeader{}
+	if err := h.Decode(pkt); err != ErrDecodeHeader {
+		t.Fatalf("expected ErrDecodeHeader for truncated body, got %v", err)
+	}
+}
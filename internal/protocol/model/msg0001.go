@@ -0,0 +1,30 @@
+package model
+
+import "encoding/binary"
+
+// Msg0001 终端通用应答，终端收到平台下发的指令后上报，用于确认指令已送达
+type Msg0001 struct {
+	Header             *MsgHeader `json:"header"`
+	AnswerSerialNumber uint16     `json:"answerSerialNumber"` // 应答流水号，对应平台指令的流水号
+	AnswerMessageID    uint16     `json:"answerMessageId"`    // 应答ID，对应平台指令的ID
+	Result             ResultCode `json:"result"`             // 结果，0成功/确认，1失败，2消息有误，3不支持
+}
+
+func (m *Msg0001) GetHeader() *MsgHeader { return m.Header }
+
+func (m *Msg0001) Decode(header *MsgHeader, body []byte) error {
+	if len(body) < 5 {
+		return ErrDecodeHeader
+	}
+
+	m.Header = header
+	m.AnswerSerialNumber = binary.BigEndian.Uint16(body[0:2])
+	m.AnswerMessageID = binary.BigEndian.Uint16(body[2:4])
+	m.Result = ResultCode(body[4])
+
+	return nil
+}
+
+func init() {
+	RegisterMsg(0x0001, func() JT808Msg { return &Msg0001{} })
+}
@@ -0,0 +1,110 @@
+package model
+
+import "sync"
+
+// ReplyIDer 由下行指令对应的上行请求消息实现，声明该请求应当用哪个MsgID应答，
+// 使GenCmd不必为每种指令硬编码应答MsgID（例如0x0100注册请求声明应答MsgID为0x8100）
+type ReplyIDer interface {
+	ReplyID() uint16
+}
+
+var (
+	registryMu   sync.RWMutex
+	msgFactories = map[uint16]func() JT808Msg{}
+	cmdFactories = map[uint16]func() JT808Cmd{}
+)
+
+// RegisterMsg 注册一种上行消息的MsgID与其构造函数，供解码器按MsgHeader.MsgID查找。
+// 用于接入厂商自定义的扩展消息（如0xF000段），不需要改动解码器本身。
+func RegisterMsg(id uint16, factory func() JT808Msg) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	msgFactories[id] = factory
+}
+
+// RegisterCmd 注册一种下行指令的MsgID与其构造函数
+func RegisterCmd(id uint16, factory func() JT808Cmd) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	cmdFactories[id] = factory
+}
+
+// NewMsg 按MsgID构造对应的上行消息实例，ok为false表示该MsgID未注册
+func NewMsg(id uint16) (msg JT808Msg, ok bool) {
+	registryMu.RLock()
+	factory, ok := msgFactories[id]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return factory(), true
+}
+
+// NewCmd 按MsgID构造对应的下行指令实例，ok为false表示该MsgID未注册
+func NewCmd(id uint16) (cmd JT808Cmd, ok bool) {
+	registryMu.RLock()
+	factory, ok := cmdFactories[id]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return factory(), true
+}
+
+// replyIDOrDefault 优先使用请求消息声明的ReplyID，未实现该接口时退回调用方给出的默认值，
+// 兼容尚未迁移到ReplyIDer的历史消息类型
+func replyIDOrDefault(msg JT808Msg, def uint16) uint16 {
+	if r, ok := msg.(ReplyIDer); ok {
+		return r.ReplyID()
+	}
+	return def
+}
+
+func init() {
+	RegisterCmd(0x8001, func() JT808Cmd { return &Cmd8001{} })
+	RegisterCmd(0x8100, func() JT808Cmd { return &Cmd8100{} })
+	RegisterCmd(0x8003, func() JT808Cmd { return &Cmd8003{} })
+}
+
+// RegistryConfig 由配置文件加载，控制启动时哪些MsgID生效，便于运营方按需裁剪协议面
+type RegistryConfig struct {
+	EnabledMsgIDs []uint16 `json:"enabledMsgIds"`
+	EnabledCmdIDs []uint16 `json:"enabledCmdIds"`
+}
+
+// ApplyConfig 按配置过滤已注册的消息/指令工厂，只保留配置中列出的MsgID。
+// 两个列表都为空时视为不限制，保留全部已注册项。
+func ApplyConfig(cfg *RegistryConfig) {
+	if cfg == nil {
+		return
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if len(cfg.EnabledMsgIDs) > 0 {
+		enabled := toSet(cfg.EnabledMsgIDs)
+		for id := range msgFactories {
+			if !enabled[id] {
+				delete(msgFactories, id)
+			}
+		}
+	}
+
+	if len(cfg.EnabledCmdIDs) > 0 {
+		enabled := toSet(cfg.EnabledCmdIDs)
+		for id := range cmdFactories {
+			if !enabled[id] {
+				delete(cmdFactories, id)
+			}
+		}
+	}
+}
+
+func toSet(ids []uint16) map[uint16]bool {
+	set := make(map[uint16]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
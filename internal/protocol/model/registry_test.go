@@ -0,0 +1,146 @@
+package model
+
+import (
+	"sync"
+	"testing"
+)
+
+// snapshotRegistry 保存当前的注册表状态，测试结束后还原，避免互相污染
+func snapshotRegistry(t *testing.T) {
+	t.Helper()
+
+	registryMu.Lock()
+	savedMsg := make(map[uint16]func() JT808Msg, len(msgFactories))
+	for id, f := range msgFactories {
+		savedMsg[id] = f
+	}
+	savedCmd := make(map[uint16]func() JT808Cmd, len(cmdFactories))
+	for id, f := range cmdFactories {
+		savedCmd[id] = f
+	}
+	registryMu.Unlock()
+
+	t.Cleanup(func() {
+		registryMu.Lock()
+		msgFactories = savedMsg
+		cmdFactories = savedCmd
+		registryMu.Unlock()
+	})
+}
+
+func TestRegistryRegisterAndLookupCmd(t *testing.T) {
+	snapshotRegistry(t)
+
+	RegisterCmd(0xF001, func() JT808Cmd { return &Cmd8001{} })
+
+	cmd, ok := NewCmd(0xF001)
+	if !ok {
+		t.Fatalf("expected registered custom cmd id to be found")
+	}
+	if _, isCmd8001 := cmd.(*Cmd8001); !isCmd8001 {
+		t.Fatalf("expected factory to produce a *Cmd8001")
+	}
+
+	if _, ok := NewCmd(0xFFFF); ok {
+		t.Fatalf("expected unregistered cmd id to report not found")
+	}
+}
+
+func TestRegistryRegisterAndLookupMsg(t *testing.T) {
+	snapshotRegistry(t)
+
+	RegisterMsg(0xF001, func() JT808Msg { return &Msg0001{} })
+
+	msg, ok := NewMsg(0xF001)
+	if !ok {
+		t.Fatalf("expected registered custom msg id to be found")
+	}
+	if _, isMsg0001 := msg.(*Msg0001); !isMsg0001 {
+		t.Fatalf("expected factory to produce a *Msg0001")
+	}
+
+	if _, ok := NewMsg(0xFFFF); ok {
+		t.Fatalf("expected unregistered msg id to report not found")
+	}
+}
+
+func TestRegistryBuiltinsRegisteredAtInit(t *testing.T) {
+	snapshotRegistry(t)
+
+	for _, id := range []uint16{0x8001, 0x8100, 0x8003} {
+		if _, ok := NewCmd(id); !ok {
+			t.Fatalf("expected built-in cmd 0x%04X to be registered", id)
+		}
+	}
+
+	if _, ok := NewMsg(0x0001); !ok {
+		t.Fatalf("expected built-in msg 0x0001 to be registered")
+	}
+}
+
+func TestApplyConfigFiltersDisabledCmdIDs(t *testing.T) {
+	snapshotRegistry(t)
+
+	ApplyConfig(&RegistryConfig{EnabledCmdIDs: []uint16{0x8001}})
+
+	if _, ok := NewCmd(0x8001); !ok {
+		t.Fatalf("expected 0x8001 to remain enabled")
+	}
+	if _, ok := NewCmd(0x8100); ok {
+		t.Fatalf("expected 0x8100 to be disabled after ApplyConfig")
+	}
+	if _, ok := NewCmd(0x8003); ok {
+		t.Fatalf("expected 0x8003 to be disabled after ApplyConfig")
+	}
+}
+
+func TestApplyConfigFiltersDisabledMsgIDs(t *testing.T) {
+	snapshotRegistry(t)
+
+	RegisterMsg(0xF002, func() JT808Msg { return &Msg0001{} })
+
+	ApplyConfig(&RegistryConfig{EnabledMsgIDs: []uint16{0x0001}})
+
+	if _, ok := NewMsg(0x0001); !ok {
+		t.Fatalf("expected 0x0001 to remain enabled")
+	}
+	if _, ok := NewMsg(0xF002); ok {
+		t.Fatalf("expected 0xF002 to be disabled after ApplyConfig")
+	}
+}
+
+func TestApplyConfigNilOrEmptyKeepsEverything(t *testing.T) {
+	snapshotRegistry(t)
+
+	ApplyConfig(nil)
+	ApplyConfig(&RegistryConfig{})
+
+	for _, id := range []uint16{0x8001, 0x8100, 0x8003} {
+		if _, ok := NewCmd(id); !ok {
+			t.Fatalf("expected cmd 0x%04X to remain registered when config doesn't restrict it", id)
+		}
+	}
+	if _, ok := NewMsg(0x0001); !ok {
+		t.Fatalf("expected msg 0x0001 to remain registered when config doesn't restrict it")
+	}
+}
+
+// TestRegistryConcurrentAccess 并发注册/查找不应该触发"concurrent map read and map write"
+func TestRegistryConcurrentAccess(t *testing.T) {
+	snapshotRegistry(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		id := uint16(0xF100 + i)
+		go func() {
+			defer wg.Done()
+			RegisterCmd(id, func() JT808Cmd { return &Cmd8001{} })
+		}()
+		go func() {
+			defer wg.Done()
+			NewCmd(id)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrSerialNumberExhausted = errors.New("no free serial number available, all in-flight")
+)
+
+// SerialSource 分配流水号的统一接口，SerialAllocator与SnowflakeSerialAllocator都实现它，
+// 使GenCmd可以在两者之间切换而不需要知道具体实现
+type SerialSource interface {
+	Next(phoneNumber string) (uint16, error)
+}
+
+// SerialStore 持久化每个终端最后下发的流水号，用于断线重连后延续分配，不从0重新计数。
+// 默认提供内存实现，接入方可以实现Redis/BoltDB等持久化存储替换掉内存实现。
+type SerialStore interface {
+	Load(phoneNumber string) (last uint16, ok bool, err error)
+	Save(phoneNumber string, serialNumber uint16) error
+}
+
+// MemSerialStore 进程内存储，重启后丢失，适合单实例部署或测试
+type MemSerialStore struct {
+	mu   sync.Mutex
+	last map[string]uint16
+}
+
+// NewMemSerialStore 构造一个内存SerialStore
+func NewMemSerialStore() *MemSerialStore {
+	return &MemSerialStore{last: make(map[string]uint16)}
+}
+
+func (s *MemSerialStore) Load(phoneNumber string) (uint16, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last, ok := s.last[phoneNumber]
+	return last, ok, nil
+}
+
+func (s *MemSerialStore) Save(phoneNumber string, serialNumber uint16) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last[phoneNumber] = serialNumber
+	return nil
+}
+
+// ackCollision 判断某个流水号是否仍有AckTracker中等待应答的在途指令占用它，
+// acks为nil时表示不做碰撞检测，两种分配器实现共用这一判断
+func ackCollision(acks *AckTracker, phoneNumber string, serialNumber uint16) bool {
+	if acks == nil {
+		return false
+	}
+	acks.mu.Lock()
+	defer acks.mu.Unlock()
+	for key := range acks.pending {
+		if key.PhoneNumber == phoneNumber && key.SerialNumber == serialNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// SerialAllocator 按终端手机号原子地分配消息流水号。SerialNumber是uint16，每65536条消息回绕一次，
+// 回绕时会跳过仍在AckTracker中等待应答的流水号，避免新指令与尚未确认的旧指令撞号。
+type SerialAllocator struct {
+	mu    sync.Mutex
+	store SerialStore
+	acks  *AckTracker // 可为nil，为nil时不做碰撞检测
+}
+
+// NewSerialAllocator 用给定的存储与AckTracker构造一个SerialAllocator，ackTracker传nil表示不做碰撞检测
+func NewSerialAllocator(store SerialStore, ackTracker *AckTracker) *SerialAllocator {
+	return &SerialAllocator{store: store, acks: ackTracker}
+}
+
+// Next 为指定终端分配下一个流水号，跳过仍有未确认应答占用的流水号
+func (a *SerialAllocator) Next(phoneNumber string) (uint16, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	last, _, err := a.store.Load(phoneNumber)
+	if err != nil {
+		return 0, errors.Wrap(err, "load last serial number error")
+	}
+
+	next := last + 1
+	for attempts := 0; attempts < 1<<16; attempts++ {
+		if !ackCollision(a.acks, phoneNumber, next) {
+			if err := a.store.Save(phoneNumber, next); err != nil {
+				return 0, errors.Wrap(err, "save serial number error")
+			}
+			return next, nil
+		}
+		next++
+	}
+
+	return 0, ErrSerialNumberExhausted
+}
+
+// assignSerialNumber 用DefaultSerialAllocator为应答消息头分配流水号，替换掉GenCmd默认沿用请求
+// 消息流水号的做法；未配置分配器时保留原有流水号，不影响现有行为
+func assignSerialNumber(header *MsgHeader) {
+	if DefaultSerialAllocator == nil {
+		return
+	}
+	if sn, err := DefaultSerialAllocator.Next(header.PhoneNumber); err == nil {
+		header.SerialNumber = sn
+	}
+}
+
+// DefaultSerialAllocator 全局默认的流水号分配器，GenCmd在其非nil时用它分配应答消息自身的流水号，
+// 而不是直接沿用请求消息的流水号（AnswerSerialNumber仍然镜像请求的流水号，用于应答关联）。
+// 类型是SerialSource接口，因此可以按需换成SnowflakeSerialAllocator等其他实现
+var DefaultSerialAllocator SerialSource
+
+// SnowflakeSerialAllocator 面向多实例部署的流水号分配器：把节点号/worker号编码进流水号的高位，
+// 配合各实例独立的计数器，使不同平台实例不会对同一终端分配出相同的流水号。
+// SerialNumber只有16位，因此只能取4位节点号+4位worker号+8位计数器；计数器每256条消息回绕一次，
+// 回绕时同样会跳过AckTracker中仍在等待应答的流水号，避免重蹈chunk0-5要解决的撞号问题。
+type SnowflakeSerialAllocator struct {
+	mu       sync.Mutex
+	nodeID   uint8 // 取低4位
+	workerID uint8 // 取低4位
+	counter  map[string]uint8
+	acks     *AckTracker // 可为nil，为nil时不做碰撞检测
+}
+
+// NewSnowflakeSerialAllocator 构造一个雪花式分配器，nodeID/workerID取低4位参与编码，
+// ackTracker传nil表示不做碰撞检测
+func NewSnowflakeSerialAllocator(nodeID, workerID uint8, ackTracker *AckTracker) *SnowflakeSerialAllocator {
+	return &SnowflakeSerialAllocator{
+		nodeID:   nodeID & 0x0F,
+		workerID: workerID & 0x0F,
+		counter:  make(map[string]uint8),
+		acks:     ackTracker,
+	}
+}
+
+// Next 为指定终端分配下一个流水号，高8位固定编码本实例的节点号+worker号，低8位为该终端的计数器，
+// 计数器回绕撞上仍在等待应答的流水号时跳过
+func (a *SnowflakeSerialAllocator) Next(phoneNumber string) (uint16, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	prefix := uint16(a.nodeID)<<12 | uint16(a.workerID)<<8
+
+	c := a.counter[phoneNumber]
+	for attempts := 0; attempts < 1<<8; attempts++ {
+		sn := prefix | uint16(c)
+		if !ackCollision(a.acks, phoneNumber, sn) {
+			a.counter[phoneNumber] = c + 1
+			return sn, nil
+		}
+		c++
+	}
+
+	return 0, ErrSerialNumberExhausted
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSerialAllocatorPersistsAcrossReconnect(t *testing.T) {
+	store := NewMemSerialStore()
+	allocator := NewSerialAllocator(store, nil)
+
+	first, err := allocator.Next("013912345678")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 模拟终端断线重连后用一个全新的Allocator实例（但复用同一个Store）继续分配
+	reconnected := NewSerialAllocator(store, nil)
+	second, err := reconnected.Next("013912345678")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second != first+1 {
+		t.Fatalf("expected allocation to continue from persisted last value, got %d after %d", second, first)
+	}
+}
+
+func TestSerialAllocatorSkipsPendingAckOnWraparound(t *testing.T) {
+	acks := NewAckTracker()
+	store := NewMemSerialStore()
+	store.Save("013912345678", 0xFFFE) // 即将回绕到0
+
+	allocator := NewSerialAllocator(store, acks)
+
+	// 占住下一个候选流水号0xFFFF，模拟它还在等待终端0x0001应答，分配器跳过它后回绕到0
+	acks.Register("013912345678", 0xFFFF, 0x8100, []byte{0x01}, func([]byte) error { return nil }, RetryPolicy{Timeout: time.Hour, MaxAttempts: 1})
+
+	next, err := allocator.Next("013912345678")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next == 0xFFFF {
+		t.Fatalf("expected allocator to skip the serial number with a pending ack, got %d", next)
+	}
+}
+
+func TestSnowflakeSerialAllocatorEncodesNodeAndWorker(t *testing.T) {
+	allocator := NewSnowflakeSerialAllocator(3, 5, nil)
+
+	sn, err := allocator.Next("013912345678")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantPrefix := uint16(3)<<12 | uint16(5)<<8
+	if sn&0xFF00 != wantPrefix {
+		t.Fatalf("expected node/worker prefix %#04x, got %#04x", wantPrefix, sn&0xFF00)
+	}
+}
+
+func TestSnowflakeSerialAllocatorSkipsPendingAck(t *testing.T) {
+	acks := NewAckTracker()
+	allocator := NewSnowflakeSerialAllocator(0, 0, acks)
+
+	// 占住计数器的下一个值(0)，模拟它还在等待终端0x0001应答
+	acks.Register("013912345678", 0x0000, 0x8100, []byte{0x01}, func([]byte) error { return nil }, RetryPolicy{Timeout: time.Hour, MaxAttempts: 1})
+
+	sn, err := allocator.Next("013912345678")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sn == 0x0000 {
+		t.Fatalf("expected snowflake allocator to skip the serial number with a pending ack")
+	}
+}
+
+func TestAssignSerialNumberUsesDefaultSerialAllocator(t *testing.T) {
+	prev := DefaultSerialAllocator
+	t.Cleanup(func() { DefaultSerialAllocator = prev })
+
+	DefaultSerialAllocator = NewSerialAllocator(NewMemSerialStore(), nil)
+
+	header := &MsgHeader{PhoneNumber: "013912345678", SerialNumber: 42}
+	assignSerialNumber(header)
+
+	if header.SerialNumber == 42 {
+		t.Fatalf("expected assignSerialNumber to overwrite the copied request serial number")
+	}
+}